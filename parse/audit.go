@@ -0,0 +1,119 @@
+package parse
+
+import (
+	"context"
+	"sort"
+)
+
+// maxAuditExamples is the number of distinct example values an Audit keeps for
+// each PropertyIdent
+const maxAuditExamples = 3
+
+// PropertyUsage describes how a PropertyIdent is used across audited input
+type PropertyUsage struct {
+	Ident    string
+	Count    int      // number of properties found with this PropertyIdent
+	Known    bool     // whether the PropertyIdent is defined by FF[4]
+	Examples []string // the first few distinct values found, still escaped
+
+	// Suggestions are the known PropertyIdents an unknown one might be a
+	// typo of, in alphabetical order
+	Suggestions []string
+}
+
+// Audit collects PropertyIdent usage over a corpus of SGF inputs. The zero
+// value is ready to use.
+type Audit struct {
+	Options Options // how the inputs are lexed
+
+	usage    map[string]*PropertyUsage
+	warnings []Warning
+}
+
+// Add lexes a named SGF input and records the properties found in it. If the
+// input is malformed an *Error is returned, but the properties found before
+// the problem are still recorded.
+func (a *Audit) Add(name string, data []byte) error {
+	return a.AddContext(context.Background(), name, data)
+}
+
+// AddContext is like Add, but gives up with the context's error if the
+// context is done before the whole input has been lexed
+func (a *Audit) AddContext(ctx context.Context, name string, data []byte) error {
+	if a.usage == nil {
+		a.usage = make(map[string]*PropertyUsage)
+	}
+
+	l := lexContext(ctx, name, data, a.Options.lexOptions())
+	var err error
+	var current *PropertyUsage
+	for i := range l.items {
+		switch i.typ {
+		case itemError:
+			err = l.errorFrom(i)
+		case itemWarning:
+			a.warnings = append(a.warnings, l.warningFrom(i))
+		case itemSemiColon:
+			current = nil
+		case itemPropertyIdent:
+			if len(i.val) == 0 {
+				// another value of the current property
+				continue
+			}
+			ident := string(i.val)
+			current = a.usage[ident]
+			if current == nil {
+				current = &PropertyUsage{Ident: ident, Known: knownIdents[ident]}
+				if !current.Known {
+					current.Suggestions = suggestIdents(ident)
+				}
+				a.usage[ident] = current
+			}
+			current.Count++
+		case itemPropertyValue:
+			if current != nil {
+				current.addExample(string(i.val))
+			}
+		}
+	}
+	if err == nil {
+		err = l.err
+	}
+	return err
+}
+
+// addExample keeps a value as an example if it has not been seen yet and there
+// is still room for it
+func (u *PropertyUsage) addExample(v string) {
+	if len(u.Examples) >= maxAuditExamples {
+		return
+	}
+	for _, e := range u.Examples {
+		if e == v {
+			return
+		}
+	}
+	u.Examples = append(u.Examples, v)
+}
+
+// Usage returns the recorded usage of every PropertyIdent found so far, most
+// frequent first
+func (a *Audit) Usage() []PropertyUsage {
+	usage := make([]PropertyUsage, 0, len(a.usage))
+	for _, u := range a.usage {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Count != usage[j].Count {
+			return usage[i].Count > usage[j].Count
+		}
+		return usage[i].Ident < usage[j].Ident
+	})
+	return usage
+}
+
+// Warnings returns the problems worked around while lexing the inputs added so
+// far, in the order they were found. Each Warning is named after its input.
+func (a *Audit) Warnings() []Warning {
+	return a.warnings
+}
@@ -0,0 +1,81 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	var a Audit
+
+	if err := a.Add("first", []byte("(;GM[1]KGSDE[x];B[aa]C[hi];W[bb]C[hi])")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := a.Add("second", []byte("(;AB[aa][bb][cc][dd]MULTIGOGM[0]KN[1];[xx]B[ee])")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := a.Add("broken", []byte("(;B[ff];w[gg])"))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if e, ok := err.(*Error); !ok || e.Name != "broken" || e.Pos != 8 {
+		t.Errorf("expected an *Error for broken at 8, got: %#v", err)
+	}
+	if msg := err.Error(); msg != "parse: broken:8: PropertyIdent must be upper-case letters" {
+		t.Errorf("unexpected error message: %q", msg)
+	}
+
+	exp := []PropertyUsage{
+		{Ident: "B", Count: 3, Known: true, Examples: []string{"aa", "ee", "ff"}},
+		{Ident: "C", Count: 2, Known: true, Examples: []string{"hi"}},
+		{Ident: "AB", Count: 1, Known: true, Examples: []string{"aa", "bb", "cc"}},
+		{Ident: "GM", Count: 1, Known: true, Examples: []string{"1"}},
+		{Ident: "KGSDE", Count: 1, Known: false, Examples: []string{"x"}},
+		{Ident: "KN", Count: 1, Known: false, Examples: []string{"1"}, Suggestions: []string{"AN", "GN", "KM", "KO", "LN", "MN", "N", "ON"}},
+		{Ident: "MULTIGOGM", Count: 1, Known: false, Examples: []string{"0"}},
+		{Ident: "W", Count: 1, Known: true, Examples: []string{"bb"}},
+	}
+	if got := a.Usage(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected usage:\n%+v\ngot:\n%+v", exp, got)
+	}
+}
+
+func TestAuditWarnings(t *testing.T) {
+	a := Audit{Options: Options{Mode: Lenient}}
+
+	if err := a.Add("clean", []byte("(;GM[1];B[aa])")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := a.Add("messy", []byte("junk(;GM[1]AddBlack[aa];B[bb])")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []Warning{
+		{Name: "messy", Pos: 0, Msg: "Ignored 4 bytes outside of a GameTree"},
+		{Name: "messy", Pos: 11, Msg: `Normalized PropertyIdent "AddBlack" to "AB"`},
+	}
+	if got := a.Warnings(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected warnings:\n%+v\ngot:\n%+v", exp, got)
+	}
+	if w := a.Warnings()[0].String(); w != "parse: messy:0: Ignored 4 bytes outside of a GameTree" {
+		t.Errorf("unexpected warning message: %q", w)
+	}
+}
+
+func TestSuggestIdents(t *testing.T) {
+	type testset struct {
+		ident string
+		exp   []string
+	}
+
+	pairs := []testset{
+		{"BP", []string{"AP", "B", "BL", "BM", "BR", "BT", "CP", "IP", "PB"}},
+		{"PWW", []string{"PW"}},
+		{"KGSDE", nil},
+	}
+	for _, pair := range pairs {
+		if got := suggestIdents(pair.ident); !reflect.DeepEqual(got, pair.exp) {
+			t.Errorf("%v: expected: %v, got: %v", pair.ident, pair.exp, got)
+		}
+	}
+}
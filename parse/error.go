@@ -0,0 +1,44 @@
+package parse
+
+import "fmt"
+
+// Error is a problem in the input which stopped the lexing process
+type Error struct {
+	Name string // name of the input, if it was given one
+	Pos  Pos
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return describe(e.Name, e.Pos, e.Msg)
+}
+
+// Warning is a problem in the input which the lexing process worked around,
+// like a PropertyIdent it rewrote or bytes it skipped or synthesized
+type Warning struct {
+	Name string // name of the input, if it was given one
+	Pos  Pos
+	Msg  string
+}
+
+func (w Warning) String() string {
+	return describe(w.Name, w.Pos, w.Msg)
+}
+
+// describe formats a problem at a position in a possibly named input
+func describe(name string, pos Pos, msg string) string {
+	if name == "" {
+		return fmt.Sprintf("parse: %d: %s", pos, msg)
+	}
+	return fmt.Sprintf("parse: %s:%d: %s", name, pos, msg)
+}
+
+// errorFrom turns an itemError from the lexer into an *Error
+func (l *lexer) errorFrom(i item) error {
+	return &Error{Name: l.name, Pos: i.pos, Msg: string(i.val)}
+}
+
+// warningFrom turns an itemWarning from the lexer into a Warning
+func (l *lexer) warningFrom(i item) Warning {
+	return Warning{Name: l.name, Pos: i.pos, Msg: string(i.val)}
+}
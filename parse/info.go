@@ -0,0 +1,103 @@
+package parse
+
+import "context"
+
+// Info holds the game-info of one game, taken from the properties of the root
+// node of its GameTree
+type Info struct {
+	Properties map[string][]string // raw PropertyValues by PropertyIdent
+
+	// Warnings are the problems worked around while lexing the GameTree's
+	// root node. Those found outside of the GameTrees go with the next
+	// GameTree, or the last one at the end of the input.
+	Warnings []Warning
+}
+
+// Get returns the first value of a property decoded as SimpleText, or the
+// empty string if the property is missing. Decoding is done in the Default
+// mode, which never fails; use Options.DecodeSimpleText on the Properties to
+// decode them strictly.
+func (i Info) Get(ident string) string {
+	vals := i.Properties[ident]
+	if len(vals) == 0 {
+		return ""
+	}
+	v, _ := DecodeSimpleText([]byte(vals[0]))
+	return string(v)
+}
+
+// GameInfo returns the Info of every top-level GameTree in the input. Only the
+// root nodes are lexed, the rest of each GameTree is skipped with a simple
+// bracket-aware scan, which makes this much cheaper than lexing everything
+// when building indexes. If the input is malformed the Infos found before the
+// problem are returned along with an *Error.
+func GameInfo(data []byte) ([]Info, error) {
+	return Options{}.GameInfo(data)
+}
+
+// GameInfoContext is like GameInfo, but gives up with the context's error if
+// the context is done before the whole input has been lexed
+func GameInfoContext(ctx context.Context, data []byte) ([]Info, error) {
+	return Options{}.GameInfoContext(ctx, data)
+}
+
+// GameInfo is like the package level GameInfo, but lexes the input as
+// configured by the Options
+func (o Options) GameInfo(data []byte) ([]Info, error) {
+	return o.GameInfoContext(context.Background(), data)
+}
+
+// GameInfoContext is like the package level GameInfoContext, but lexes the
+// input as configured by the Options
+func (o Options) GameInfoContext(ctx context.Context, data []byte) ([]Info, error) {
+	var infos []Info
+	var err error
+	var depth int
+	var ident string
+	var pending []Warning
+
+	lo := o.lexOptions()
+	lo.rootOnly = true
+	l := lexContext(ctx, "", data, lo)
+	for i := range l.items {
+		switch i.typ {
+		case itemError:
+			err = l.errorFrom(i)
+		case itemWarning:
+			if depth == 0 {
+				pending = append(pending, l.warningFrom(i))
+				continue
+			}
+			info := &infos[len(infos)-1]
+			info.Warnings = append(info.Warnings, l.warningFrom(i))
+		case itemOpenParen:
+			if depth == 0 {
+				infos = append(infos, Info{Properties: make(map[string][]string), Warnings: pending})
+				pending = nil
+			}
+			depth++
+		case itemCloseParen:
+			depth--
+		case itemSemiColon:
+			ident = ""
+		case itemPropertyIdent:
+			if len(i.val) > 0 {
+				ident = string(i.val)
+			}
+		case itemPropertyValue:
+			if ident == "" {
+				continue
+			}
+			props := infos[len(infos)-1].Properties
+			props[ident] = append(props[ident], string(i.val))
+		}
+	}
+	if len(pending) > 0 && len(infos) > 0 {
+		info := &infos[len(infos)-1]
+		info.Warnings = append(info.Warnings, pending...)
+	}
+	if err == nil {
+		err = l.err
+	}
+	return infos, err
+}
@@ -0,0 +1,152 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGameInfo(t *testing.T) {
+	sgf := `junk
+	(;GM[1]PB[Lee Sedol]PW[Gu Li]RO[3 (final)]AB[aa][bb]
+		;B[cc]C[a comment with (parens) and \] brackets]
+		(;W[dd]PB[not root])
+		(;W[ee]))
+	()
+	(;PB[Honinbo\
+Shusaku]RE[B+R];B[aa])`
+
+	infos, err := GameInfo([]byte(sgf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []Info{
+		{Properties: map[string][]string{
+			"GM": {"1"},
+			"PB": {"Lee Sedol"},
+			"PW": {"Gu Li"},
+			"RO": {"3 (final)"},
+			"AB": {"aa", "bb"},
+		}},
+		{Properties: map[string][]string{}},
+		{Properties: map[string][]string{
+			"PB": {"Honinbo\\\nShusaku"},
+			"RE": {"B+R"},
+		}},
+	}
+	if !reflect.DeepEqual(infos, exp) {
+		t.Errorf("expected:\n%v\ngot:\n%v", exp, infos)
+	}
+
+	if pb := infos[2].Get("PB"); pb != "HoninboShusaku" {
+		t.Errorf("expected decoded PB, got: %q", pb)
+	}
+	if ev := infos[0].Get("EV"); ev != "" {
+		t.Errorf("expected missing EV to be empty, got: %q", ev)
+	}
+}
+
+func TestGameInfoTruncated(t *testing.T) {
+	infos, err := GameInfo([]byte("(;PB[a]PW[b];B[aa];W[b"))
+	if err == nil || err.Error() != "parse: 22: unexpected EOF" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Get("PW") != "b" {
+		t.Errorf("expected the root info before the error, got: %v", infos)
+	}
+}
+
+func TestGameInfoNestedRoot(t *testing.T) {
+	infos, err := GameInfo([]byte("((;PB[a];B[aa]))(;PB[b])"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []Info{
+		{Properties: map[string][]string{"PB": {"a"}}},
+		{Properties: map[string][]string{"PB": {"b"}}},
+	}
+	if !reflect.DeepEqual(infos, exp) {
+		t.Errorf("expected:\n%v\ngot:\n%v", exp, infos)
+	}
+}
+
+func TestGameInfoStrictDecoding(t *testing.T) {
+	sgf := []byte("(;PB[bad\x01\\]name])")
+
+	infos, err := Options{Mode: Strict}.GameInfo(sgf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pb := infos[0].Get("PB"); pb != "bad\x01]name" {
+		t.Errorf("expected Get to decode PB in the Default mode, got: %q", pb)
+	}
+	if pb, err := (Options{Mode: Strict}).DecodeSimpleText([]byte(infos[0].Properties["PB"][0])); err == nil {
+		t.Errorf("expected strict decoding of PB to fail, got: %q", pb)
+	}
+}
+
+func TestGameInfoValuelessIdent(t *testing.T) {
+	infos, err := GameInfo([]byte("(;PB[x])(;[junk]PW[y])"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []Info{
+		{Properties: map[string][]string{"PB": {"x"}}},
+		{Properties: map[string][]string{"PW": {"y"}}},
+	}
+	if !reflect.DeepEqual(infos, exp) {
+		t.Errorf("expected:\n%v\ngot:\n%v", exp, infos)
+	}
+}
+
+func TestGameInfoWarnings(t *testing.T) {
+	sgf := []byte("junk(;GM[1]FOO[x];B[aa]) more junk\n(;PB[y]) trailing")
+
+	infos, err := Options{Mode: Lenient}.GameInfo(sgf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []Info{
+		{
+			Properties: map[string][]string{"GM": {"1"}, "FOO": {"x"}},
+			Warnings: []Warning{
+				{Pos: 0, Msg: "Ignored 4 bytes outside of a GameTree"},
+				{Pos: 11, Msg: "Found PropertyIdent wider than 2 characters"},
+			},
+		},
+		{
+			Properties: map[string][]string{"PB": {"y"}},
+			Warnings: []Warning{
+				{Pos: 25, Msg: "Ignored 10 bytes outside of a GameTree"},
+				{Pos: 44, Msg: "Ignored 8 bytes outside of a GameTree"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(infos, exp) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", exp, infos)
+	}
+}
+
+func TestGameInfoRepair(t *testing.T) {
+	sgf := []byte("(;PB[a]PW[b];B[aa];W[b")
+
+	infos, err := Options{Repair: true}.GameInfo(sgf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []Info{
+		{
+			Properties: map[string][]string{"PB": {"a"}, "PW": {"b"}},
+			Warnings: []Warning{
+				{Pos: 22, Msg: "Synthesized 1 missing right parentheses at EOF"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(infos, exp) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", exp, infos)
+	}
+}
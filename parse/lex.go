@@ -9,7 +9,11 @@ lexProperty method. ¯\_(ツ)_/¯
 
 package parse
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 // Pos is a position within a buffer
 type Pos int
@@ -61,15 +65,30 @@ func (i item) String() string {
 
 type stateFn func(*lexer) stateFn
 
+// lexOptions control how tolerant the lexer is of input which does not follow
+// the FF[4] grammar
+type lexOptions struct {
+	mode   Mode
+	repair bool // complete truncated input at EOF, emitting warnings
+
+	rootOnly bool // skip everything after the root node of each GameTree
+}
+
 type lexer struct {
 	name      string
 	input     []byte
+	opts      lexOptions
 	state     stateFn
 	pos       Pos
 	start     Pos
 	width     Pos
 	items     chan item
+	ctx       context.Context
+	cancel    context.CancelFunc
+	err       error // why the lexer was stopped early, set before items closes
 	treeDepth int
+	rootSeen  bool
+	nodeSeen  bool // whether the innermost open GameTree has a node yet
 }
 
 // next gets the next byte in the buffer or eof if there are no more bytes and
@@ -97,10 +116,28 @@ func (l *lexer) backup() {
 	l.pos -= l.width
 }
 
+// send puts an item on the items channel, or drops it if the lexer has been
+// stopped and nobody is listening anymore
+func (l *lexer) send(i item) {
+	select {
+	case l.items <- i:
+	case <-l.ctx.Done():
+		l.err = l.ctx.Err()
+	}
+}
+
 // emit sends an item on on the items channel and advances the start to the
 // current position
 func (l *lexer) emit(t itemType) {
-	l.items <- item{t, l.start, l.input[l.start:l.pos]}
+	l.send(item{t, l.start, l.input[l.start:l.pos]})
+	l.start = l.pos
+}
+
+// emitVal sends an item with the given value, rather than the bytes between
+// start and the current position, on the items channel and advances the start
+// to the current position
+func (l *lexer) emitVal(t itemType, val []byte) {
+	l.send(item{t, l.start, val})
 	l.start = l.pos
 }
 
@@ -113,38 +150,74 @@ func (l *lexer) ignore() {
 // errorf emits a formatted error string as bytes on the items channel and
 // halts the lexing process
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.items <- item{itemError, l.start, []byte(fmt.Sprintf(format, args...))}
+	l.send(item{itemError, l.start, []byte(fmt.Sprintf(format, args...))})
 	return nil
 }
 
 // emitWarning emits a formatted warning string as bytes on the items channel
 // and does not interrupt the lexing process
 func (l *lexer) emitWarning(format string, args ...interface{}) {
-	l.items <- item{itemWarning, l.start, []byte(fmt.Sprintf(format, args...))}
+	l.send(item{itemWarning, l.start, []byte(fmt.Sprintf(format, args...))})
 }
 
 // lex starts the lexing process on a named slice of bytes
-func lex(name string, input []byte) *lexer {
+func lex(name string, input []byte, opts lexOptions) *lexer {
+	return lexContext(context.Background(), name, input, opts)
+}
+
+// lexContext starts the lexing process on a named slice of bytes, stopping
+// early if the context is done. A lexer stopped early closes its items channel
+// without necessarily sending an itemEOF or itemError, and records why in err.
+func lexContext(ctx context.Context, name string, input []byte, opts lexOptions) *lexer {
+	ctx, cancel := context.WithCancel(ctx)
 	l := &lexer{
-		name:  name,
-		input: input,
-		items: make(chan item),
+		name:   name,
+		input:  input,
+		opts:   opts,
+		items:  make(chan item),
+		ctx:    ctx,
+		cancel: cancel,
 	}
 	go l.run()
 	return l
 }
 
-// run process the lexer state until there is no more state to process
+// run process the lexer state until there is no more state to process or the
+// lexer has been stopped
 func (l *lexer) run() {
-	for l.state = lexBytes; l.state != nil; {
+	for l.state = lexBytes; l.state != nil && !l.stopped(); {
 		l.state = l.state(l)
 	}
+	if l.state != nil {
+		l.err = l.ctx.Err()
+	}
+	l.cancel()
 	close(l.items)
 }
 
+// drain stops the lexing process and discards any items which have not been
+// received yet. Consumers that stop reading items before the channel is closed
+// must call drain, or the lexing goroutine is left blocked forever.
+func (l *lexer) drain() {
+	l.cancel()
+	for range l.items {
+	}
+}
+
+// stopped reports whether drain has been called or the context is done
+func (l *lexer) stopped() bool {
+	select {
+	case <-l.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
 // lexBytes handles the outermost level of the bytes, ignoring all characters
 // except the opening parentheses. The closing parentheses are also handled,
-// but only to detect extra closing parentheses.
+// but only to detect extra closing parentheses. In Strict mode anything other
+// than whitespace is an error and in Lenient mode it is warned about.
 func lexBytes(l *lexer) stateFn {
 Loop:
 	for {
@@ -157,17 +230,50 @@ Loop:
 		case eof:
 			break Loop
 		default:
-			l.ignore()
+			if !l.ignoreJunk(n, "a GameTree", "()") {
+				return nil
+			}
 		}
 	}
 	l.emit(itemEOF)
 	return nil
 }
 
+// ignoreJunk deals with a byte found somewhere only whitespace belongs. Other
+// than whitespace it is an error in Strict mode, while in Lenient mode the
+// whole run of bytes up to the next of the stop bytes is skipped with a
+// warning. It reports whether lexing can go on.
+func (l *lexer) ignoreJunk(n byte, where string, stops string) bool {
+	switch {
+	case isWhitespace(n):
+		l.ignore()
+	case l.opts.mode == Strict:
+		l.backup()
+		l.ignore()
+		l.errorf("unexpected %q outside of %s", n, where)
+		return false
+	case l.opts.mode == Lenient:
+		l.backup()
+		l.ignore()
+		for p := l.peek(); p != eof && strings.IndexByte(stops, p) < 0; p = l.peek() {
+			_ = l.next()
+		}
+		l.emitWarning("Ignored %d bytes outside of %s", l.pos-l.start, where)
+		l.ignore()
+	default:
+		l.ignore()
+	}
+	return true
+}
+
 // lexOpenParen emits the open parantheses character and increments the treeDepth
 func lexOpenParen(l *lexer) stateFn {
+	if l.treeDepth == 0 {
+		l.rootSeen = false
+	}
 	l.emit(itemOpenParen)
 	l.treeDepth++
+	l.nodeSeen = false
 	return lexTree
 }
 
@@ -179,6 +285,8 @@ func lexCloseParen(l *lexer) stateFn {
 		return l.errorf("too many right parentheses")
 	}
 	l.emit(itemCloseParen)
+	// a GameTree's variations can only follow its own nodes
+	l.nodeSeen = true
 	if l.treeDepth > 0 {
 		return lexTree
 	} else {
@@ -187,36 +295,121 @@ func lexCloseParen(l *lexer) stateFn {
 }
 
 // lexTree deals with the upper levels of of a GameTree, handling semicolons,
-// opening and closing parentheses. Other characters are ignored.
+// opening and closing parentheses. Other characters are ignored, warned about
+// in Lenient mode or an error in Strict mode.
 func lexTree(l *lexer) stateFn {
 	for {
 		n := l.next()
 		switch n {
-		case ';':
-			return lexSemiColon
-		case '(':
+		case ';', '(':
+			if l.opts.rootOnly && l.rootSeen {
+				return lexSkipTree
+			}
+			if n == ';' {
+				return lexSemiColon
+			}
 			return lexOpenParen
 		case ')':
 			return lexCloseParen
 		case eof:
+			if l.opts.repair {
+				return lexRepairEOF
+			}
 			return l.errorf("unexpected EOF")
 		default:
-			l.ignore()
+			if !l.ignoreJunk(n, "a node", ";()") {
+				return nil
+			}
+		}
+	}
+}
+
+// lexRepairEOF closes the GameTrees left open by a truncated input with
+// synthesized closing parentheses. FF[4] does not allow empty GameTrees, so if
+// the input was cut off right after a GameTree was opened, an empty node is
+// synthesized for it first.
+func lexRepairEOF(l *lexer) stateFn {
+	if l.treeDepth > 0 && !l.nodeSeen {
+		l.emitWarning("Synthesized empty node for GameTree cut off at EOF")
+		l.emitVal(itemSemiColon, []byte(";"))
+		l.nodeSeen = true
+	}
+	l.emitWarning("Synthesized %d missing right parentheses at EOF", l.treeDepth)
+	for ; l.treeDepth > 0; l.treeDepth-- {
+		l.emitVal(itemCloseParen, []byte(")"))
+	}
+	l.emit(itemEOF)
+	return nil
+}
+
+// lexSkipTree skips the rest of the GameTree the lexer is in, minding the
+// brackets around PropertyValues. GameTrees opened while skipping are skipped
+// whole, and the closing parenthesis of the GameTree skipping started in is
+// handed to lexCloseParen, so every emitted opening parenthesis still gets a
+// closing one.
+func lexSkipTree(l *lexer) stateFn {
+	// the ';' or '(' that got us here is part of what gets skipped
+	l.backup()
+	var nested int
+	for {
+		switch l.next() {
+		case '[':
+		ValueLoop:
+			for {
+				switch l.next() {
+				case eof:
+					return l.skippedToEOF()
+				case '\\':
+					_ = l.next()
+				case ']':
+					break ValueLoop
+				}
+			}
+		case '(':
+			nested++
+		case ')':
+			if nested == 0 {
+				l.backup()
+				l.ignore()
+				_ = l.next()
+				return lexCloseParen
+			}
+			nested--
+		case eof:
+			return l.skippedToEOF()
 		}
 	}
 }
 
+// skippedToEOF handles running into the end of the input while skipping a
+// GameTree
+func (l *lexer) skippedToEOF() stateFn {
+	l.ignore()
+	if l.opts.repair {
+		return lexRepairEOF
+	}
+	return l.errorf("unexpected EOF")
+}
+
 // lexSemiColon emits a semicolon and changes context to deal with Properties
 func lexSemiColon(l *lexer) stateFn {
+	l.rootSeen = true
+	l.nodeSeen = true
 	l.emit(itemSemiColon)
 	return lexProperty
 }
 
+// isWhitespace reports whether the byte is whitespace the lexer may skip. CR
+// counts, so files with CRLF or CR line endings lex like LF ones.
+func isWhitespace(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\r' || b == '\t' || b == '\v' || b == '\f'
+}
+
 // consumeWhitespace ignores as much whitespace as it can find
 func (l *lexer) consumeWhitespace() {
 	for {
 		n := l.next()
-		if n == ' ' || n == '\n' || n == '\t' {
+		if isWhitespace(n) {
 			l.ignore()
 		} else {
 			l.backup()
@@ -231,34 +424,90 @@ func (l *lexer) consumeWhitespace() {
 func lexProperty(l *lexer) stateFn {
 	l.consumeWhitespace()
 
+	var strayWhitespace, softBreak, lowerCase bool
 IdentLoop:
 	for {
 		n := l.next()
 		switch {
 		case n == eof:
+			if l.opts.repair {
+				if l.pos > l.start {
+					l.emitWarning("Dropped incomplete PropertyIdent %q at EOF", l.input[l.start:l.pos])
+					l.ignore()
+				}
+				return lexRepairEOF
+			}
 			return l.errorf("unexpected EOF")
 		case n == '[':
 			l.backup()
 			break IdentLoop
+		case l.opts.mode == Lenient && isWhitespace(n):
+			// some old clients break lines in the middle of a PropertyIdent
+			// or put spaces between it and its value
+			strayWhitespace = true
+		case l.opts.mode == Lenient && n == '\\' && linebreakWidth(l.input[l.pos:]) > 0:
+			// others escape the line breaks, as if the ident were Text
+			l.pos += Pos(linebreakWidth(l.input[l.pos:]))
+			softBreak = true
+		case l.opts.mode == Lenient && n >= 'a' && n <= 'z':
+			// FF[1] allowed long names like "AddBlack", where only the
+			// upper-case letters make up the actual PropertyIdent
+			lowerCase = true
 		case n < 'A' || n > 'Z':
 			return l.errorf("PropertyIdent must be upper-case letters")
 		}
 	}
-	if identWidth := l.pos - l.start; identWidth > 2 {
+
+	ident := l.input[l.start:l.pos]
+	if strayWhitespace || softBreak || lowerCase {
+		stripped := stripIdent(ident)
+		if len(stripped) == 0 {
+			return l.errorf("PropertyIdent must be upper-case letters")
+		}
+		switch {
+		case lowerCase:
+			l.emitWarning("Normalized PropertyIdent %q to %q", ident, stripped)
+		case softBreak:
+			l.emitWarning("Removed soft line break from PropertyIdent %q", ident)
+		default:
+			l.emitWarning("Removed whitespace from PropertyIdent %q", ident)
+		}
+		ident = stripped
+	}
+	if len(ident) > 2 {
+		if l.opts.mode == Strict {
+			return l.errorf("PropertyIdent wider than 2 characters")
+		}
 		l.emitWarning("Found PropertyIdent wider than 2 characters")
 	}
-	l.emit(itemPropertyIdent)
+	l.emitVal(itemPropertyIdent, ident)
 	_ = l.next()
 	l.emit(itemOpenBracket)
 
+	var danglingEscape bool
 ValueLoop:
 	for {
 		n := l.next()
 		switch {
 		case n == eof:
+			if l.opts.repair {
+				if danglingEscape {
+					// the escape would swallow the synthesized bracket
+					l.pos--
+					l.emit(itemPropertyValue)
+					l.emitWarning("Dropped dangling escape character at EOF")
+					l.pos++
+					l.ignore()
+				} else {
+					l.emit(itemPropertyValue)
+				}
+				l.emitWarning("Synthesized missing right bracket at EOF")
+				l.emitVal(itemCloseBracket, []byte("]"))
+				return lexRepairEOF
+			}
 			return l.errorf("unexpected EOF")
 		case n == '\\':
-			_ = l.next()
+			danglingEscape = l.next() == eof
 		case n == ']':
 			l.backup()
 			break ValueLoop
@@ -278,3 +527,15 @@ ValueLoop:
 		return lexProperty
 	}
 }
+
+// stripIdent returns a copy of a PropertyIdent with everything except the
+// upper-case letters removed
+func stripIdent(ident []byte) []byte {
+	stripped := make([]byte, 0, len(ident))
+	for _, b := range ident {
+		if b >= 'A' && b <= 'Z' {
+			stripped = append(stripped, b)
+		}
+	}
+	return stripped
+}
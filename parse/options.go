@@ -0,0 +1,38 @@
+package parse
+
+// Mode selects how input which does not follow the FF[4] grammar is treated
+type Mode int
+
+const (
+	// Default fails on lower-case PropertyIdents, warns about PropertyIdents
+	// wider than 2 characters and silently ignores anything outside of the
+	// GameTrees and their nodes
+	Default Mode = iota
+
+	// Strict fails on lower-case PropertyIdents, on PropertyIdents wider than
+	// 2 characters and on anything but whitespace outside of the GameTrees
+	// and their nodes
+	Strict
+
+	// Lenient strips stray whitespace and lower-case letters from
+	// PropertyIdents and warns about PropertyIdents wider than 2 characters
+	// and anything outside of the GameTrees and their nodes
+	Lenient
+)
+
+// Options configure how input is parsed. The zero value uses the Default mode
+// without repairs.
+type Options struct {
+	Mode Mode
+
+	// Repair completes input truncated at EOF by closing the open
+	// PropertyValue and GameTrees instead of failing. A GameTree cut off
+	// before its first node gets an empty node. Each repair is reported as
+	// a Warning on the results.
+	Repair bool
+}
+
+// lexOptions returns the lexer configuration for the Options
+func (o Options) lexOptions() lexOptions {
+	return lexOptions{mode: o.Mode, repair: o.Repair}
+}
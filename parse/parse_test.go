@@ -1,7 +1,10 @@
 package parse
 
 import (
+	"context"
 	"fmt"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 )
@@ -16,7 +19,7 @@ func checkLexerOutput(t *testing.T, expected []expectedItem, l *lexer) {
 		select {
 		case lv := <-l.items:
 			if v.item.typ != lv.typ {
-				t.Errorf("%v[%d]: expected type: %v, got: %v", l.name, i, v.item.typ, lv.typ, lv)
+				t.Errorf("%v[%d]: expected type: %v, got: %v", l.name, i, v.item.typ, lv.typ)
 			}
 			if !v.ignoreVal {
 				var notequal bool
@@ -49,6 +52,7 @@ func TestLexer(t *testing.T) {
 	type testset struct {
 		name string
 		sgf  string
+		opts lexOptions
 		exp  []expectedItem
 	}
 
@@ -184,6 +188,487 @@ func TestLexer(t *testing.T) {
 				{item{typ: itemError, val: []byte("unexpected EOF")}, false},
 			},
 		},
+		{
+			name: "whitespace in PropertyIdent",
+			sgf:  "(;S\nZ[19]B [aa])",
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemError, val: []byte("PropertyIdent must be upper-case letters")}, false},
+			},
+		},
+		{
+			name: "lenient whitespace in PropertyIdent",
+			sgf:  "(;S\nZ[19]B [aa])",
+			opts: lexOptions{mode: Lenient},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte(`Removed whitespace from PropertyIdent "S\nZ"`)}, false},
+				{item{typ: itemPropertyIdent, val: []byte("SZ")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("19")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte(`Removed whitespace from PropertyIdent "B "`)}, false},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "lenient long PropertyIdents",
+			sgf:  "(;AddBlack[aa]White[bb];size[19])",
+			opts: lexOptions{mode: Lenient},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte(`Normalized PropertyIdent "AddBlack" to "AB"`)}, false},
+				{item{typ: itemPropertyIdent, val: []byte("AB")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte(`Normalized PropertyIdent "White" to "W"`)}, false},
+				{item{typ: itemPropertyIdent, val: []byte("W")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("bb")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemError, val: []byte("PropertyIdent must be upper-case letters")}, false},
+			},
+		},
+		{
+			name: "repair unclosed gametrees",
+			sgf:  "(;B[aa](;W[bb]",
+			opts: lexOptions{repair: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("W")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("bb")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte("Synthesized 2 missing right parentheses at EOF")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "repair gametree cut off before its first node",
+			sgf:  "(;B[aa](",
+			opts: lexOptions{repair: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte("Synthesized empty node for GameTree cut off at EOF")}, false},
+				{item{typ: itemSemiColon, val: []byte(";")}, false},
+				{item{typ: itemWarning, val: []byte("Synthesized 2 missing right parentheses at EOF")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "repair variation cut off after a closed sibling",
+			sgf:  "(;B[aa](;W[bb])",
+			opts: lexOptions{repair: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("W")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("bb")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte("Synthesized 1 missing right parentheses at EOF")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "repair unfinished PropertyIdent",
+			sgf:  "(;B[aa]AB",
+			opts: lexOptions{repair: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte(`Dropped incomplete PropertyIdent "AB" at EOF`)}, false},
+				{item{typ: itemWarning, val: []byte("Synthesized 1 missing right parentheses at EOF")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "repair unfinished PropertyValue",
+			sgf:  "(;C[cut off mid",
+			opts: lexOptions{repair: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("C")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("cut off mid")}, false},
+				{item{typ: itemWarning, val: []byte("Synthesized missing right bracket at EOF")}, false},
+				{item{typ: itemCloseBracket, val: []byte("]")}, false},
+				{item{typ: itemWarning, val: []byte("Synthesized 1 missing right parentheses at EOF")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "root nodes only",
+			sgf:  "(;A[1](;B[2]C[)];D[3])(;E[4]))(;F[5];G[6])",
+			opts: lexOptions{rootOnly: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("A")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("1")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("F")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("5")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "junk between gametrees",
+			sgf:  "Game 1:\n(;B[aa])\n-- cut here --\n(;W[bb])\nthe end",
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("W")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("bb")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "strict junk between gametrees",
+			sgf:  "(;B[aa])\n-- cut here --\n(;W[bb])",
+			opts: lexOptions{mode: Strict},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemError, val: []byte(`unexpected '-' outside of a GameTree`)}, false},
+			},
+		},
+		{
+			name: "strict whitespace between gametrees",
+			sgf:  " \t(;B[aa])\n\n(;W[bb])\n",
+			opts: lexOptions{mode: Strict},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("W")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("bb")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "strict wide PropertyIdent",
+			sgf:  "(;GM[1]MULTIGOGM[1])",
+			opts: lexOptions{mode: Strict},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("GM")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("1")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemError, val: []byte("PropertyIdent wider than 2 characters")}, false},
+			},
+		},
+		{
+			name: "lenient junk between gametrees",
+			sgf:  "(;B[aa])\n-- cut here --\n(;W[bb])",
+			opts: lexOptions{mode: Lenient},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte("Ignored 15 bytes outside of a GameTree")}, false},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("W")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("bb")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "CRLF and CR line endings",
+			sgf:  "(;GM[1]\r\nC[one\r\ntwo\rthree]\r;B[aa]\r\n\r\n)\r\n",
+			opts: lexOptions{mode: Strict},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("GM")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("1")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("C")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("one\r\ntwo\rthree")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("aa")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "nested root node only",
+			sgf:  "((;A[1];B[2](;C[3])))(;D[4])",
+			opts: lexOptions{rootOnly: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("A")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("1")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("D")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("4")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "repair root node only",
+			sgf:  "(;A[1](;B[2]",
+			opts: lexOptions{rootOnly: true, repair: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("A")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("1")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte("Synthesized 1 missing right parentheses at EOF")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "junk inside gametrees",
+			sgf:  "(junk;A[1](;B[2])xyz(;C[3]))",
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("A")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("1")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("2")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("C")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("3")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "strict junk before a node",
+			sgf:  "(junk;B[aa])",
+			opts: lexOptions{mode: Strict},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemError, val: []byte(`unexpected 'j' outside of a node`)}, false},
+			},
+		},
+		{
+			name: "strict junk between variations",
+			sgf:  "(;A[1](;B[2])xyz(;C[3]))",
+			opts: lexOptions{mode: Strict},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("A")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("1")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("2")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemError, val: []byte(`unexpected 'x' outside of a node`)}, false},
+			},
+		},
+		{
+			name: "lenient junk inside gametrees",
+			sgf:  "(junk ;A[1](;B[2])xyz(;C[3]))",
+			opts: lexOptions{mode: Lenient},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte("Ignored 5 bytes outside of a node")}, false},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("A")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("1")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("B")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("2")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte("Ignored 3 bytes outside of a node")}, false},
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("C")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("3")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "repair empty node",
+			sgf:  "(;",
+			opts: lexOptions{repair: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte("Synthesized 1 missing right parentheses at EOF")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "repair dangling escape",
+			sgf:  "(;C[abc\\",
+			opts: lexOptions{repair: true},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemPropertyIdent, val: []byte("C")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("abc")}, false},
+				{item{typ: itemWarning, val: []byte("Dropped dangling escape character at EOF")}, false},
+				{item{typ: itemWarning, val: []byte("Synthesized missing right bracket at EOF")}, false},
+				{item{typ: itemCloseBracket, val: []byte("]")}, false},
+				{item{typ: itemWarning, val: []byte("Synthesized 1 missing right parentheses at EOF")}, false},
+				{item{typ: itemCloseParen, val: []byte(")")}, false},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
+		{
+			name: "soft line break in PropertyIdent",
+			sgf:  "(;S\\\nZ[19])",
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemError, val: []byte("PropertyIdent must be upper-case letters")}, false},
+			},
+		},
+		{
+			name: "lenient soft line breaks in PropertyIdents",
+			sgf:  "(;S\\\nZ[19]P\\\r\nB[x])",
+			opts: lexOptions{mode: Lenient},
+			exp: []expectedItem{
+				{item{typ: itemOpenParen, val: dontcare}, true},
+				{item{typ: itemSemiColon, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte(`Removed soft line break from PropertyIdent "S\\\nZ"`)}, false},
+				{item{typ: itemPropertyIdent, val: []byte("SZ")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("19")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemWarning, val: []byte(`Removed soft line break from PropertyIdent "P\\\r\nB"`)}, false},
+				{item{typ: itemPropertyIdent, val: []byte("PB")}, false},
+				{item{typ: itemOpenBracket, val: dontcare}, true},
+				{item{typ: itemPropertyValue, val: []byte("x")}, false},
+				{item{typ: itemCloseBracket, val: dontcare}, true},
+				{item{typ: itemCloseParen, val: dontcare}, true},
+				{item{typ: itemEOF, val: dontcare}, true},
+			},
+		},
 		{
 			name: "something realistic",
 			sgf: `
@@ -272,7 +757,50 @@ func TestLexer(t *testing.T) {
 		},
 	}
 	for _, pair := range pairs {
-		l := lex(pair.name, []byte(pair.sgf))
+		l := lex(pair.name, []byte(pair.sgf), pair.opts)
 		checkLexerOutput(t, pair.exp, l)
 	}
 }
+
+func TestLexerDrain(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	sgf := []byte("(" + strings.Repeat(";B[aa]W[bb]", 1000) + ")")
+	for i := 0; i < 100; i++ {
+		l := lex("abandoned", sgf, lexOptions{})
+		<-l.items
+		l.drain()
+	}
+
+	finished := lex("finished", []byte("()"), lexOptions{})
+	for range finished.items {
+	}
+	finished.drain()
+
+	// goroutines may take a moment to exit after closing their channels
+	deadline := time.Now().Add(3 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("leaked %d lexer goroutines", after-before)
+	}
+}
+
+func TestLexerContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sgf := []byte("(" + strings.Repeat(";B[aa]W[bb]", 1000) + ")")
+
+	l := lexContext(ctx, "canceled", sgf, lexOptions{})
+	<-l.items
+	cancel()
+
+	for i := range l.items {
+		if i.typ == itemEOF || i.typ == itemError {
+			t.Errorf("unexpected %v after cancel", i)
+		}
+	}
+	if l.err != context.Canceled {
+		t.Errorf("expected the lexer to record context.Canceled, got: %v", l.err)
+	}
+}
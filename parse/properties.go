@@ -0,0 +1,80 @@
+package parse
+
+import "sort"
+
+// knownIdents holds the PropertyIdents defined by the FF[4] specification,
+// including the game specific ones
+var knownIdents = map[string]bool{
+	// moves
+	"B": true, "KO": true, "MN": true, "W": true,
+	// setup
+	"AB": true, "AE": true, "AW": true, "PL": true,
+	// node annotation
+	"C": true, "DM": true, "GB": true, "GW": true, "HO": true, "N": true,
+	"UC": true, "V": true,
+	// move annotation
+	"BM": true, "DO": true, "IT": true, "TE": true,
+	// markup
+	"AR": true, "CR": true, "DD": true, "LB": true, "LN": true, "MA": true,
+	"SL": true, "SQ": true, "TR": true,
+	// root
+	"AP": true, "CA": true, "FF": true, "GM": true, "ST": true, "SZ": true,
+	// game info
+	"AN": true, "BR": true, "BT": true, "CP": true, "DT": true, "EV": true,
+	"GN": true, "GC": true, "ON": true, "OT": true, "PB": true, "PC": true,
+	"PW": true, "RE": true, "RO": true, "RU": true, "SO": true, "TM": true,
+	"US": true, "WR": true, "WT": true,
+	// timing
+	"BL": true, "OB": true, "OW": true, "WL": true,
+	// miscellaneous
+	"FG": true, "PM": true, "VW": true,
+	// Go
+	"HA": true, "KM": true, "TB": true, "TW": true,
+	// Lines of Action
+	"AS": true, "IP": true, "IY": true, "SE": true, "SU": true,
+	// Hex
+	"IS": true,
+	// Backgammon
+	"CO": true, "CV": true, "DI": true, "MI": true,
+}
+
+// suggestIdents returns the known PropertyIdents which are a single edit away
+// from an unknown one: one letter added, removed or replaced, or two adjacent
+// letters swapped
+func suggestIdents(ident string) []string {
+	var suggestions []string
+	for known := range knownIdents {
+		if known != ident && editDistance(ident, known) == 1 {
+			suggestions = append(suggestions, known)
+		}
+	}
+	sort.Strings(suggestions)
+	return suggestions
+}
+
+// editDistance returns the optimal string alignment distance between a and b,
+// which is the Levenshtein distance with transpositions of adjacent letters
+// counting as a single edit
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
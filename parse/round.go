@@ -0,0 +1,78 @@
+package parse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Round is the decoded value of an RO property. The spec suggests values be
+// written as RO[xx (tt)] where xx is the number of the round and tt the type
+// of round (final, playoff, league, ...), but real files are loose about it.
+type Round struct {
+	Number int
+	Type   string
+}
+
+// ParseRound decodes an RO value into a Round. The round type is taken from a
+// trailing parenthesized part, if there is one, and the round number is the
+// first run of digits found before it, so values like "3", "3 (final)" and
+// "Round 3 (playoff)" are all understood. Signed round numbers are rejected.
+func ParseRound(v string) (Round, error) {
+	var r Round
+
+	num := strings.TrimSpace(v)
+	if open := trailingGroup(num); open >= 0 {
+		r.Type = strings.TrimSpace(num[open+1 : len(num)-1])
+		num = strings.TrimSpace(num[:open])
+	}
+
+	start := strings.IndexAny(num, "0123456789")
+	if start < 0 {
+		return Round{}, fmt.Errorf("parse: no round number in %q", v)
+	}
+	if start > 0 && (num[start-1] == '-' || num[start-1] == '+') {
+		return Round{}, fmt.Errorf("parse: signed round number in %q", v)
+	}
+	end := start
+	for end < len(num) && num[end] >= '0' && num[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(num[start:end])
+	if err != nil {
+		return Round{}, fmt.Errorf("parse: invalid round number in %q: %v", v, err)
+	}
+	r.Number = n
+
+	return r, nil
+}
+
+// trailingGroup returns the index of the '(' which opens the balanced
+// parenthesized group at the end of s, or -1 if s does not end in one
+func trailingGroup(s string) int {
+	if !strings.HasSuffix(s, ")") {
+		return -1
+	}
+	var depth int
+	for i := len(s) - 1; i >= 0; i-- {
+		switch s[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// String formats the Round the way the spec suggests, as "xx (tt)", leaving
+// off the type part if it is empty
+func (r Round) String() string {
+	if r.Type == "" {
+		return strconv.Itoa(r.Number)
+	}
+	return fmt.Sprintf("%d (%s)", r.Number, r.Type)
+}
@@ -0,0 +1,48 @@
+package parse
+
+import "testing"
+
+func TestParseRound(t *testing.T) {
+	type testset struct {
+		val string
+		exp Round
+		err bool
+		str string
+	}
+
+	pairs := []testset{
+		{val: "3", exp: Round{3, ""}, str: "3"},
+		{val: "3 (final)", exp: Round{3, "final"}, str: "3 (final)"},
+		{val: "  12(playoff) ", exp: Round{12, "playoff"}, str: "12 (playoff)"},
+		{val: "Round 5 (league)", exp: Round{5, "league"}, str: "5 (league)"},
+		{val: "2nd", exp: Round{2, ""}, str: "2"},
+		{val: "3 (semi (a))", exp: Round{3, "semi (a)"}, str: "3 (semi (a))"},
+		{val: "4 (b) (final)", exp: Round{4, "final"}, str: "4 (final)"},
+		{val: "-3", err: true},
+		{val: "+3 (final)", err: true},
+		{val: "(final)", err: true},
+		{val: "", err: true},
+	}
+	for _, pair := range pairs {
+		r, err := ParseRound(pair.val)
+		if pair.err {
+			if err == nil {
+				t.Errorf("%q: expected an error, got: %v", pair.val, r)
+			}
+			if r != (Round{}) {
+				t.Errorf("%q: expected the zero Round with the error, got: %#v", pair.val, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", pair.val, err)
+			continue
+		}
+		if r != pair.exp {
+			t.Errorf("%q: expected: %#v, got: %#v", pair.val, pair.exp, r)
+		}
+		if s := r.String(); s != pair.str {
+			t.Errorf("%q: expected string: %q, got: %q", pair.val, pair.str, s)
+		}
+	}
+}
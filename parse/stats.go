@@ -0,0 +1,70 @@
+package parse
+
+import "context"
+
+// Stats are rough counts of the contents of an SGF input
+type Stats struct {
+	Games     int  // number of top-level GameTrees
+	Nodes     int  // number of nodes in all of the GameTrees
+	Moves     int  // number of B and W properties in all of the nodes
+	Plausible bool // whether the input lexed cleanly into at least one node
+
+	Warnings []Warning // problems worked around while lexing the input
+}
+
+// QuickStats counts the games, nodes and moves in the input in a single
+// lexing pass without building anything, which makes it a cheap check of
+// whether an input is worth parsing fully. If the input is malformed the
+// counts up to the problem are returned along with an *Error.
+func QuickStats(data []byte) (Stats, error) {
+	return Options{}.QuickStats(data)
+}
+
+// QuickStatsContext is like QuickStats, but gives up with the context's error
+// if the context is done before the whole input has been lexed
+func QuickStatsContext(ctx context.Context, data []byte) (Stats, error) {
+	return Options{}.QuickStatsContext(ctx, data)
+}
+
+// QuickStats is like the package level QuickStats, but lexes the input as
+// configured by the Options
+func (o Options) QuickStats(data []byte) (Stats, error) {
+	return o.QuickStatsContext(context.Background(), data)
+}
+
+// QuickStatsContext is like the package level QuickStatsContext, but lexes
+// the input as configured by the Options
+func (o Options) QuickStatsContext(ctx context.Context, data []byte) (Stats, error) {
+	var s Stats
+	var err error
+	var depth int
+
+	l := lexContext(ctx, "", data, o.lexOptions())
+	for i := range l.items {
+		switch i.typ {
+		case itemError:
+			err = l.errorFrom(i)
+		case itemWarning:
+			s.Warnings = append(s.Warnings, l.warningFrom(i))
+		case itemOpenParen:
+			if depth == 0 {
+				s.Games++
+			}
+			depth++
+		case itemCloseParen:
+			depth--
+		case itemSemiColon:
+			s.Nodes++
+		case itemPropertyIdent:
+			if len(i.val) == 1 && (i.val[0] == 'B' || i.val[0] == 'W') {
+				s.Moves++
+			}
+		}
+	}
+	if err == nil {
+		err = l.err
+	}
+
+	s.Plausible = err == nil && s.Nodes > 0
+	return s, err
+}
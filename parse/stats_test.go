@@ -0,0 +1,145 @@
+package parse
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuickStats(t *testing.T) {
+	type testset struct {
+		name string
+		sgf  string
+		exp  Stats
+		err  bool
+	}
+
+	pairs := []testset{
+		{
+			name: "empty string",
+			sgf:  "",
+			exp:  Stats{},
+		},
+		{
+			name: "not sgf",
+			sgf:  "<html><body>hello</body></html>",
+			exp:  Stats{},
+		},
+		{
+			name: "empty gametree",
+			sgf:  "()",
+			exp:  Stats{Games: 1},
+		},
+		{
+			name: "two games with variations",
+			sgf: `(;FF[4]GM[1]SZ[19];B[aa];W[bb](;B[cc];W[dd])(;B[ee]C[hi]))
+			(;GM[1]AB[aa][bb];W[cc])`,
+			exp: Stats{Games: 2, Nodes: 8, Moves: 6, Plausible: true},
+		},
+		{
+			name: "truncated",
+			sgf:  "(;GM[1];B[aa];W[b",
+			exp:  Stats{Games: 1, Nodes: 3, Moves: 2},
+			err:  true,
+		},
+	}
+	for _, pair := range pairs {
+		s, err := QuickStats([]byte(pair.sgf))
+		if (err != nil) != pair.err {
+			t.Errorf("%v: unexpected error: %v", pair.name, err)
+		}
+		if e, ok := err.(*Error); ok && e.Name != "" {
+			t.Errorf("%v: expected an unnamed error, got: %v", pair.name, err)
+		}
+		if !reflect.DeepEqual(s, pair.exp) {
+			t.Errorf("%v: expected: %+v, got: %+v", pair.name, pair.exp, s)
+		}
+	}
+}
+
+func TestQuickStatsOptions(t *testing.T) {
+	sgf := []byte("(;GM[1]\nAddBlack[aa];B[bb];W[c")
+
+	if s, err := QuickStats(sgf); err == nil || s.Plausible {
+		t.Errorf("expected default options to fail, got: %+v, %v", s, err)
+	}
+
+	s, err := Options{Mode: Lenient, Repair: true}.QuickStats(sgf)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	exp := Stats{Games: 1, Nodes: 3, Moves: 2, Plausible: true, Warnings: []Warning{
+		{Pos: 8, Msg: `Normalized PropertyIdent "AddBlack" to "AB"`},
+		{Pos: 30, Msg: "Synthesized missing right bracket at EOF"},
+		{Pos: 30, Msg: "Synthesized 1 missing right parentheses at EOF"},
+	}}
+	if !reflect.DeepEqual(s, exp) {
+		t.Errorf("expected: %+v, got: %+v", exp, s)
+	}
+}
+
+func TestQuickStatsRepair(t *testing.T) {
+	sgf := []byte("(;GM[1];B[aa](;W[bb]C[cut off\\")
+
+	s, err := Options{Repair: true}.QuickStats(sgf)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	exp := Stats{Games: 1, Nodes: 3, Moves: 2, Plausible: true, Warnings: []Warning{
+		{Pos: 29, Msg: "Dropped dangling escape character at EOF"},
+		{Pos: 30, Msg: "Synthesized missing right bracket at EOF"},
+		{Pos: 30, Msg: "Synthesized 2 missing right parentheses at EOF"},
+	}}
+	if !reflect.DeepEqual(s, exp) {
+		t.Errorf("expected: %+v, got: %+v", exp, s)
+	}
+}
+
+func TestQuickStatsWarnings(t *testing.T) {
+	sgf := []byte("junk(;GM[1]FOO[x];B[aa]) more junk\n(;W[bb])")
+
+	s, err := Options{Mode: Lenient}.QuickStats(sgf)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	exp := Stats{Games: 2, Nodes: 3, Moves: 2, Plausible: true, Warnings: []Warning{
+		{Pos: 0, Msg: "Ignored 4 bytes outside of a GameTree"},
+		{Pos: 11, Msg: "Found PropertyIdent wider than 2 characters"},
+		{Pos: 25, Msg: "Ignored 10 bytes outside of a GameTree"},
+	}}
+	if !reflect.DeepEqual(s, exp) {
+		t.Errorf("expected: %+v, got: %+v", exp, s)
+	}
+	if w := s.Warnings[0].String(); w != "parse: 0: Ignored 4 bytes outside of a GameTree" {
+		t.Errorf("unexpected warning message: %v", w)
+	}
+}
+
+func TestQuickStatsContext(t *testing.T) {
+	sgf := []byte("(" + strings.Repeat(";B[aa]W[bb]", 1000) + ")")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if s, err := QuickStatsContext(ctx, sgf); err != context.Canceled || s.Plausible {
+		t.Errorf("expected a canceled implausible result, got: %+v, %v", s, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	s, err := QuickStatsContext(ctx, sgf)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if exp := (Stats{Games: 1, Nodes: 1000, Moves: 2000, Plausible: true}); !reflect.DeepEqual(s, exp) {
+		t.Errorf("expected: %+v, got: %+v", exp, s)
+	}
+}
+
+func TestQuickStatsErrorMessage(t *testing.T) {
+	_, err := QuickStats([]byte("(;B[aa]"))
+	if err == nil || err.Error() != "parse: 7: unexpected EOF" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
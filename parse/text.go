@@ -0,0 +1,112 @@
+package parse
+
+import "fmt"
+
+// DecodeText decodes the raw bytes of a Text PropertyValue as the lexer found
+// them. Escaped characters are unescaped, soft line breaks (line breaks
+// preceded by a "\") are removed, hard line breaks are normalized to LF and
+// all other whitespace is converted to spaces. A dangling escape character is
+// kept literally and control characters are passed through.
+func DecodeText(val []byte) ([]byte, error) {
+	return Options{}.DecodeText(val)
+}
+
+// DecodeSimpleText decodes the raw bytes of a SimpleText PropertyValue. It
+// works like DecodeText, except that hard line breaks are converted to spaces
+// too.
+func DecodeSimpleText(val []byte) ([]byte, error) {
+	return Options{}.DecodeSimpleText(val)
+}
+
+// DecodeText is like the package level DecodeText, except that in Strict mode
+// values which could not have been written by an FF[4] conforming application
+// are rejected: values ending in a dangling escape character and values
+// containing control characters other than whitespace.
+func (o Options) DecodeText(val []byte) ([]byte, error) {
+	return decodeText(val, false, o.Mode == Strict)
+}
+
+// DecodeSimpleText is like the package level DecodeSimpleText, except that
+// in Strict mode values are rejected the same way as by Options.DecodeText
+func (o Options) DecodeSimpleText(val []byte) ([]byte, error) {
+	return decodeText(val, true, o.Mode == Strict)
+}
+
+func decodeText(val []byte, simple, strict bool) ([]byte, error) {
+	out := make([]byte, 0, len(val))
+	for i := 0; i < len(val); i++ {
+		b := val[i]
+		switch {
+		case b == '\\':
+			if i+1 == len(val) {
+				if strict {
+					return nil, fmt.Errorf("parse: dangling escape character at position %d", i)
+				}
+				out = append(out, b)
+				continue
+			}
+			i++
+			if n := linebreakWidth(val[i:]); n > 0 {
+				// soft line break
+				i += n - 1
+				continue
+			}
+			if isTextSpace(val[i]) {
+				out = append(out, ' ')
+			} else {
+				out = append(out, val[i])
+			}
+		case linebreakWidth(val[i:]) > 0:
+			n := linebreakWidth(val[i:])
+			if simple {
+				out = append(out, ' ')
+			} else {
+				out = append(out, '\n')
+			}
+			i += n - 1
+		case isTextSpace(b):
+			out = append(out, ' ')
+		case b < ' ' || b == 0x7f:
+			if strict {
+				return nil, fmt.Errorf("parse: control character %q at position %d", b, i)
+			}
+			out = append(out, b)
+		default:
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+// linebreakWidth returns the width of the line break at the start of the bytes
+// or 0 if there isn't one. All of LF, CR, LF CR and CR LF are line breaks.
+func linebreakWidth(b []byte) int {
+	if len(b) == 0 || (b[0] != '\n' && b[0] != '\r') {
+		return 0
+	}
+	if len(b) > 1 && (b[1] == '\n' || b[1] == '\r') && b[1] != b[0] {
+		return 2
+	}
+	return 1
+}
+
+// isTextSpace reports whether the byte is whitespace, other than a line break,
+// which Text and SimpleText values convert to a space
+func isTextSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\v' || b == '\f'
+}
+
+// SplitCompose splits the raw bytes of a composed PropertyValue at the first
+// unescaped ':'. The parts are still escaped. If there is no unescaped ':' the
+// whole value is returned as the first part and ok is false.
+func SplitCompose(val []byte) (first, second []byte, ok bool) {
+	for i := 0; i < len(val); i++ {
+		switch val[i] {
+		case '\\':
+			i++
+		case ':':
+			return val[:i], val[i+1:], true
+		}
+	}
+	return val, nil, false
+}
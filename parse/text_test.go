@@ -0,0 +1,90 @@
+package parse
+
+import "testing"
+
+func TestDecodeText(t *testing.T) {
+	type testset struct {
+		name   string
+		val    string
+		text   string
+		simple string
+	}
+
+	pairs := []testset{
+		{"plain", "hello 世界", "hello 世界", "hello 世界"},
+		{"escaped bracket at the end", `a[1\]`, "a[1]", "a[1]"},
+		{"escaped backslash", `C:\\go`, `C:\go`, `C:\go`},
+		{"escaped colon", `W\:R`, "W:R", "W:R"},
+		{"escaped ordinary character", `\a\b`, "ab", "ab"},
+		{"tabs and vertical tabs", "a\tb\vc", "a b c", "a b c"},
+		{"escaped tab", "a\\\tb", "a b", "a b"},
+		{"hard line break", "a\nb", "a\nb", "a b"},
+		{"soft line break LF", "a\\\nb", "ab", "ab"},
+		{"soft line break CR LF", "a\\\r\nb", "ab", "ab"},
+		{"soft line break LF CR", "a\\\n\rb", "ab", "ab"},
+		{"soft line break CR", "a\\\rb", "ab", "ab"},
+		{"two hard line breaks", "a\n\nb", "a\n\nb", "a  b"},
+		{"hard line break CR LF", "a\r\nb", "a\nb", "a b"},
+		{"hard line break LF CR", "a\n\rb", "a\nb", "a b"},
+		{"hard line break CR", "a\rb", "a\nb", "a b"},
+		{"mixed hard line breaks", "a\r\nb\rc\nd\r\n\r\ne", "a\nb\nc\nd\n\ne", "a b c d  e"},
+		{"dangling escape", `a\`, `a\`, `a\`},
+	}
+	for _, pair := range pairs {
+		text, err := DecodeText([]byte(pair.val))
+		if err != nil {
+			t.Errorf("%v: unexpected Text error: %v", pair.name, err)
+		} else if string(text) != pair.text {
+			t.Errorf("%v: expected Text: %q, got: %q", pair.name, pair.text, text)
+		}
+		simple, err := DecodeSimpleText([]byte(pair.val))
+		if err != nil {
+			t.Errorf("%v: unexpected SimpleText error: %v", pair.name, err)
+		} else if string(simple) != pair.simple {
+			t.Errorf("%v: expected SimpleText: %q, got: %q", pair.name, pair.simple, simple)
+		}
+	}
+}
+
+func TestStrictTextDecoding(t *testing.T) {
+	strict := Options{Mode: Strict}
+
+	for _, val := range []string{`a\`, "a\x00b", "a\x1bb"} {
+		if text, err := strict.DecodeText([]byte(val)); err == nil {
+			t.Errorf("%q: expected an error, got: %q", val, text)
+		}
+		if text, err := strict.DecodeSimpleText([]byte(val)); err == nil {
+			t.Errorf("%q: expected a SimpleText error, got: %q", val, text)
+		}
+		if text, err := DecodeText([]byte(val)); err != nil || string(text) != val {
+			t.Errorf("%q: expected the default mode to pass it through, got: %q, %v", val, text, err)
+		}
+	}
+	if text, err := strict.DecodeText([]byte("a\\\tb\nc")); err != nil || string(text) != "a b\nc" {
+		t.Errorf("expected %q, got: %q, %v", "a b\nc", text, err)
+	}
+}
+
+func TestSplitCompose(t *testing.T) {
+	type testset struct {
+		val    string
+		first  string
+		second string
+		ok     bool
+	}
+
+	pairs := []testset{
+		{"aa:bb", "aa", "bb", true},
+		{`a\:b:c:d`, `a\:b`, "c:d", true},
+		{`a\\:b`, `a\\`, "b", true},
+		{"aa", "aa", "", false},
+		{":", "", "", true},
+	}
+	for _, pair := range pairs {
+		first, second, ok := SplitCompose([]byte(pair.val))
+		if string(first) != pair.first || string(second) != pair.second || ok != pair.ok {
+			t.Errorf("%q: expected: %q %q %v, got: %q %q %v",
+				pair.val, pair.first, pair.second, pair.ok, first, second, ok)
+		}
+	}
+}